@@ -0,0 +1,96 @@
+package ssdeep
+
+import (
+	"encoding/binary"
+	"hash"
+
+	"lukechampine.com/blake3"
+)
+
+// BlockHasher computes the per-block digest a Hasher folds into each
+// signature character. Update absorbs one byte of the current block;
+// Sum64 peeks at the accumulator's current digest without resetting it
+// (only the low 6 bits are used, matching the signature's base64
+// alphabet). New returns a fresh, zero-state instance of the same kind,
+// so a Hasher can start a new block without knowing the concrete type.
+type BlockHasher interface {
+	Update(b byte)
+	Sum64() uint32
+	New() BlockHasher
+}
+
+// fnvBlockHasher is the classic FNV-1 block hash spamsum has always
+// used. It is the default so existing signatures stay byte-compatible.
+type fnvBlockHasher struct {
+	h uint32
+}
+
+// NewFNVBlockHasher returns the classic FNV-1 BlockHasher.
+func NewFNVBlockHasher() BlockHasher {
+	return &fnvBlockHasher{h: fnvOffset}
+}
+
+func (f *fnvBlockHasher) Update(b byte) { f.h = f.h*fnvPrime ^ uint32(b) }
+func (f *fnvBlockHasher) Sum64() uint32 { return f.h }
+func (f *fnvBlockHasher) New() BlockHasher { return NewFNVBlockHasher() }
+
+// blake3BlockHasher truncates a streaming Blake3 digest to the block
+// hash's 6 usable bits instead of FNV, for callers who want stronger
+// collision resistance on the per-block hash when ssdeep is fed
+// adversarial inputs. Signatures produced with it carry the flavorBlake3
+// prefix so they're never silently compared against classic ones.
+type blake3BlockHasher struct {
+	h   hash.Hash
+	buf [1]byte
+}
+
+// NewBlake3BlockHasher returns a Blake3-backed BlockHasher.
+func NewBlake3BlockHasher() BlockHasher {
+	return &blake3BlockHasher{h: blake3.New(32, nil)}
+}
+
+func (b *blake3BlockHasher) Update(c byte) {
+	b.buf[0] = c
+	b.h.Write(b.buf[:])
+}
+
+func (b *blake3BlockHasher) Sum64() uint32 {
+	return binary.LittleEndian.Uint32(b.h.Sum(nil)[:4])
+}
+
+func (b *blake3BlockHasher) New() BlockHasher { return NewBlake3BlockHasher() }
+
+// flavorBlake3 prefixes signatures produced with the Blake3 block
+// hasher, so Distance can tell them apart from classic FNV-based ones.
+const flavorBlake3 = "b3:"
+
+// HashOptions configures HashWithOptions.
+type HashOptions struct {
+	// BlockHasher selects the per-block hash implementation. Nil uses
+	// the classic FNV hash.
+	BlockHasher BlockHasher
+}
+
+// HashWithOptions computes the ssdeep signature of data using opts to
+// pick the block-hash implementation. The zero value of HashOptions
+// reproduces the classic, FNV-based signature; signatures produced with
+// the Blake3 block hasher are tagged with the flavorBlake3 prefix so
+// Distance can refuse to compare across flavors. Signatures from any
+// other BlockHasher implementation carry no prefix.
+func HashWithOptions(data []byte, opts HashOptions) (string, error) {
+	bh := opts.BlockHasher
+	if bh == nil {
+		bh = NewFNVBlockHasher()
+	}
+
+	hsh := newHasher(blockMin, bh)
+	if _, err := hsh.Write(data); err != nil {
+		return "", err
+	}
+	sig := hsh.Signature()
+
+	if _, ok := bh.(*blake3BlockHasher); ok {
+		sig = flavorBlake3 + sig
+	}
+	return sig, nil
+}
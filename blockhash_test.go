@@ -0,0 +1,91 @@
+package ssdeep
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashWithOptionsDefaultMatchesClassicHasher(t *testing.T) {
+	data := chunkedData(5000)
+
+	got, err := HashWithOptions(data, HashOptions{})
+	if err != nil {
+		t.Fatalf("HashWithOptions: %v", err)
+	}
+
+	hsh := New().(*Hasher)
+	hsh.Write(data)
+	want := hsh.Signature()
+
+	if got != want {
+		t.Fatalf("HashWithOptions({}) = %q, want %q (byte-for-byte match with the classic Hasher)", got, want)
+	}
+}
+
+func TestHashWithOptionsExplicitFNVMatchesDefault(t *testing.T) {
+	data := chunkedData(1234)
+
+	withDefault, err := HashWithOptions(data, HashOptions{})
+	if err != nil {
+		t.Fatalf("HashWithOptions({}): %v", err)
+	}
+	withExplicit, err := HashWithOptions(data, HashOptions{BlockHasher: NewFNVBlockHasher()})
+	if err != nil {
+		t.Fatalf("HashWithOptions(FNV): %v", err)
+	}
+	if withDefault != withExplicit {
+		t.Fatalf("explicit NewFNVBlockHasher() = %q, want it to match the default %q", withExplicit, withDefault)
+	}
+}
+
+func TestBlake3SignatureRoundTrip(t *testing.T) {
+	data := chunkedData(5000)
+
+	sig, err := HashWithOptions(data, HashOptions{BlockHasher: NewBlake3BlockHasher()})
+	if err != nil {
+		t.Fatalf("HashWithOptions(Blake3): %v", err)
+	}
+	if !strings.HasPrefix(sig, flavorBlake3) {
+		t.Fatalf("Blake3 signature %q missing the %q flavor prefix", sig, flavorBlake3)
+	}
+
+	flavor, blockSize, part1, part2, err := splitSsdeep(sig)
+	if err != nil {
+		t.Fatalf("splitSsdeep(%q): %v", sig, err)
+	}
+	if flavor != flavorBlake3 {
+		t.Fatalf("splitSsdeep flavor = %q, want %q", flavor, flavorBlake3)
+	}
+	if blockSize == 0 || part1 == "" || part2 == "" {
+		t.Fatalf("splitSsdeep(%q) = (%q, %d, %q, %q), want a fully parsed signature", sig, flavor, blockSize, part1, part2)
+	}
+
+	score, err := Distance(sig, sig)
+	if err != nil {
+		t.Fatalf("Distance(sig, sig): %v", err)
+	}
+	if score != 100 {
+		t.Fatalf("Distance(sig, sig) = %d, want 100", score)
+	}
+
+	classic, err := HashWithOptions(data, HashOptions{})
+	if err != nil {
+		t.Fatalf("HashWithOptions({}): %v", err)
+	}
+	if _, err := Distance(sig, classic); err != ErrFlavorMismatch {
+		t.Fatalf("Distance(blake3, classic) = %v, want ErrFlavorMismatch", err)
+	}
+}
+
+func TestBlockHasherNewIsIndependent(t *testing.T) {
+	for _, bh := range []BlockHasher{NewFNVBlockHasher(), NewBlake3BlockHasher()} {
+		bh.Update('a')
+		fresh := bh.New()
+		if fresh.Sum64() == bh.Sum64() && bh.Sum64() != 0 {
+			// Not a hard guarantee of non-collision, but catches the
+			// obvious bug of New() returning the same, already-mutated
+			// instance instead of a zero-state one.
+			t.Errorf("%T.New() looks like it returned the same mutated instance", bh)
+		}
+	}
+}
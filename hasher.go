@@ -0,0 +1,159 @@
+package ssdeep
+
+import (
+	"fmt"
+	"hash"
+	"io"
+)
+
+// base64Alphabet is the restricted alphabet ssdeep renders block hashes
+// with, matching the classic spamsum signature format.
+const base64Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// fnvPrime and fnvOffset are the 32-bit FNV-1 constants spamsum's
+// default block hash has always used.
+const (
+	fnvPrime  uint32 = 16777619
+	fnvOffset uint32 = 2166136261
+)
+
+// Hasher is a streaming, hash.Hash-compatible ssdeep implementation. It
+// carries the rolling state and the two growing signature strings
+// incrementally, so callers can fold arbitrarily large inputs (files,
+// network streams, tar entries) into a signature without buffering the
+// whole payload.
+type Hasher struct {
+	initialBlockSize int
+	blockSize        int
+
+	proto  BlockHasher
+	roll   rollingState
+	h, h2  BlockHasher
+	n1, n2 int
+
+	sig1, sig2 []byte
+}
+
+// New returns a Hasher seeded at the default initial block size, using
+// the classic FNV block hash.
+func New() hash.Hash {
+	return NewWithBlockSize(blockMin)
+}
+
+// NewWithBlockSize returns a Hasher that starts hashing at bs instead of
+// the default initial block size. bs is clamped up to blockMin.
+func NewWithBlockSize(bs int) hash.Hash {
+	return newHasher(bs, NewFNVBlockHasher())
+}
+
+// newHasher builds a Hasher around proto, cloning it with proto.New
+// whenever a block's accumulator needs to start fresh.
+func newHasher(bs int, proto BlockHasher) *Hasher {
+	if bs < blockMin {
+		bs = blockMin
+	}
+	hsh := &Hasher{initialBlockSize: bs, proto: proto}
+	hsh.Reset()
+	return hsh
+}
+
+// Write folds p into the running signature. It never returns an error.
+func (hsh *Hasher) Write(p []byte) (int, error) {
+	for _, b := range p {
+		hsh.writeByte(b)
+	}
+	return len(p), nil
+}
+
+func (hsh *Hasher) writeByte(b byte) {
+	hsh.roll.rollHash(b)
+	hsh.h.Update(b)
+	hsh.h2.Update(b)
+	hsh.n1++
+	hsh.n2++
+
+	rh := int(hsh.roll.rollSum())
+	if rh%hsh.blockSize == hsh.blockSize-1 {
+		hsh.sig1 = append(hsh.sig1, base64Alphabet[hsh.h.Sum64()&0x3f])
+		hsh.h, hsh.n1 = hsh.proto.New(), 0
+	}
+	if rh%(hsh.blockSize*2) == hsh.blockSize*2-1 {
+		hsh.sig2 = append(hsh.sig2, base64Alphabet[hsh.h2.Sum64()&0x3f])
+		hsh.h2, hsh.n2 = hsh.proto.New(), 0
+	}
+
+	// The block size was too small for this input: the fine-grained
+	// signature has grown past spamSumLength. Double the block size and
+	// carry on without rebuffering, promoting the coarser-grained
+	// signature accumulated so far to be the new fine-grained one.
+	if len(hsh.sig1) > spamSumLength {
+		hsh.blockSize *= 2
+		hsh.sig1 = hsh.sig2
+		hsh.sig2 = nil
+		hsh.h, hsh.n1 = hsh.h2, hsh.n2
+		hsh.h2, hsh.n2 = hsh.proto.New(), 0
+	}
+}
+
+// Signature finalizes and returns the ssdeep signature accumulated so
+// far. It does not mutate the Hasher, so it may be called repeatedly
+// (matching hash.Hash's Sum semantics) and interleaved with more Writes.
+func (hsh *Hasher) Signature() string {
+	sig1 := append([]byte(nil), hsh.sig1...)
+	sig2 := append([]byte(nil), hsh.sig2...)
+	if hsh.n1 > 0 || len(sig1) == 0 {
+		sig1 = append(sig1, base64Alphabet[hsh.h.Sum64()&0x3f])
+	}
+	if hsh.n2 > 0 || len(sig2) == 0 {
+		sig2 = append(sig2, base64Alphabet[hsh.h2.Sum64()&0x3f])
+	}
+	return fmt.Sprintf("%d:%s:%s", hsh.blockSize, sig1, sig2)
+}
+
+// Sum implements hash.Hash. Most callers want Signature instead; Sum
+// exists for drop-in compatibility with code that already streams
+// through hash.Hash (sha256, blake3, ...) and appends the signature's
+// bytes to b.
+func (hsh *Hasher) Sum(b []byte) []byte {
+	return append(b, hsh.Signature()...)
+}
+
+// Reset returns the Hasher to its initial block size so it can be
+// reused for a new input, as hash.Hash requires.
+func (hsh *Hasher) Reset() {
+	hsh.blockSize = hsh.initialBlockSize
+	hsh.roll = rollingState{}
+	hsh.h, hsh.h2 = hsh.proto.New(), hsh.proto.New()
+	hsh.n1, hsh.n2 = 0, 0
+	hsh.sig1 = hsh.sig1[:0]
+	hsh.sig2 = hsh.sig2[:0]
+}
+
+// Size returns 0: ssdeep signatures are variable-length text, not a
+// fixed-size digest, so the length Sum appends isn't known in advance.
+func (hsh *Hasher) Size() int { return 0 }
+
+// BlockSize returns 1: Write has no preferred granularity, since ssdeep
+// folds input a byte at a time internally.
+func (hsh *Hasher) BlockSize() int { return 1 }
+
+// HashReader streams r through a Hasher with a bounded internal buffer
+// and returns the resulting signature, so callers with arbitrarily large
+// inputs never need to buffer the whole payload themselves.
+func HashReader(r io.Reader) (string, error) {
+	hsh := New().(*Hasher)
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			hsh.Write(buf[:n])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return hsh.Signature(), nil
+}
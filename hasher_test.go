@@ -0,0 +1,140 @@
+package ssdeep
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+	"testing"
+)
+
+// chunkedData returns deterministic, varied bytes of length n, enough to
+// exercise the rolling hash across a wide range of values.
+func chunkedData(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i * 37 % 256)
+	}
+	return data
+}
+
+func TestHasherStreamingMatchesOneShot(t *testing.T) {
+	data := chunkedData(5000)
+
+	whole := New()
+	whole.Write(data)
+	wantSig := whole.(*Hasher).Signature()
+
+	for _, chunkSize := range []int{1, 3, 7, 64, 4096} {
+		streamed := New().(*Hasher)
+		for i := 0; i < len(data); i += chunkSize {
+			end := i + chunkSize
+			if end > len(data) {
+				end = len(data)
+			}
+			streamed.Write(data[i:end])
+		}
+		if got := streamed.Signature(); got != wantSig {
+			t.Errorf("chunkSize=%d: Signature() = %q, want %q (must match one-shot write)", chunkSize, got, wantSig)
+		}
+	}
+}
+
+func TestHasherSignatureDoesNotMutate(t *testing.T) {
+	hsh := New().(*Hasher)
+	hsh.Write(chunkedData(500))
+
+	first := hsh.Signature()
+	second := hsh.Signature()
+	if first != second {
+		t.Fatalf("Signature() changed between calls: %q != %q", first, second)
+	}
+
+	hsh.Write([]byte("more data after Signature"))
+	if got := hsh.Signature(); got == first {
+		t.Fatalf("Signature() unchanged after further writes")
+	}
+}
+
+func TestHasherResetAllowsReuse(t *testing.T) {
+	a := chunkedData(2000)
+	b := chunkedData(3000)
+
+	hsh := New().(*Hasher)
+	hsh.Write(a)
+	sigA := hsh.Signature()
+
+	hsh.Reset()
+	hsh.Write(b)
+	sigB := hsh.Signature()
+
+	fresh := New().(*Hasher)
+	fresh.Write(b)
+	wantSigB := fresh.Signature()
+
+	if sigB != wantSigB {
+		t.Fatalf("after Reset, Signature() = %q, want %q (same as a fresh Hasher on the same input)", sigB, wantSigB)
+	}
+	if sigA == sigB {
+		t.Fatalf("Signature() unchanged across Reset + different input")
+	}
+	if hsh.blockSize != hsh.initialBlockSize {
+		t.Fatalf("Reset left blockSize = %d, want initialBlockSize = %d", hsh.blockSize, hsh.initialBlockSize)
+	}
+}
+
+func TestHasherBlockSizeDoublesOnLargeInput(t *testing.T) {
+	hsh := NewWithBlockSize(blockMin).(*Hasher)
+	hsh.Write(chunkedData(200000))
+
+	if hsh.blockSize <= hsh.initialBlockSize {
+		t.Fatalf("blockSize = %d after a large input, want it to have doubled past initialBlockSize = %d", hsh.blockSize, hsh.initialBlockSize)
+	}
+}
+
+func TestHasherSumAppendsSignature(t *testing.T) {
+	hsh := New()
+	hsh.Write([]byte("hello world"))
+	prefix := []byte("prefix-")
+	got := hsh.Sum(prefix)
+	if !bytes.HasPrefix(got, prefix) {
+		t.Fatalf("Sum(prefix) = %q, want it to start with %q", got, prefix)
+	}
+	if string(got[len(prefix):]) != hsh.(*Hasher).Signature() {
+		t.Fatalf("Sum(prefix) suffix = %q, want it to equal Signature()", got[len(prefix):])
+	}
+}
+
+func TestHasherImplementsHashHash(t *testing.T) {
+	var _ hash.Hash = New()
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestHashReaderPropagatesError(t *testing.T) {
+	wantErr := errors.New("boom")
+	if _, err := HashReader(errReader{wantErr}); err != wantErr {
+		t.Fatalf("HashReader error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestHashReaderMatchesWrite(t *testing.T) {
+	data := chunkedData(10000)
+
+	hsh := New().(*Hasher)
+	hsh.Write(data)
+	want := hsh.Signature()
+
+	got, err := HashReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("HashReader: %v", err)
+	}
+	if got != want {
+		t.Fatalf("HashReader = %q, want %q", got, want)
+	}
+}
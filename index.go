@@ -0,0 +1,229 @@
+package ssdeep
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrDuplicateID is returned by Index.Add when id is already present in
+// the index.
+var ErrDuplicateID = errors.New("ssdeep: signature id already indexed")
+
+// Match is a signature found by Index.Search or Index.SearchTopK, paired
+// with its score against the query signature.
+type Match struct {
+	ID    string
+	Score int
+}
+
+// gramKey identifies one rolling-window-sized substring of a signature
+// half, tagged with the block size that half was computed at. Two halves
+// are only ever comparable (per Distance's own gating) when their block
+// sizes are equal, so indexing by this pair is enough to recover every
+// candidate Distance could score above zero.
+type gramKey struct {
+	blockSize int
+	gram      string
+}
+
+// candidate is an inverted-index posting: one signature half that
+// produced a given gramKey.
+type candidate struct {
+	id   string
+	half int // 1 for the signature's first string, 2 for its second
+}
+
+type indexedSignature struct {
+	hash      string
+	blockSize int
+	part1     string
+	part2     string
+}
+
+// Index is a concurrent-safe, in-memory inverted index over a corpus of
+// ssdeep signatures. It pre-computes the set of rollingWindow-sized
+// substrings of every stored signature half and buckets them by
+// (blockSize, substring), so Search and SearchTopK can narrow a query
+// down to a small candidate set before paying for scoreDistance, instead
+// of scoring the whole corpus pairwise.
+type Index struct {
+	mu       sync.RWMutex
+	entries  map[string]*indexedSignature
+	inverted map[gramKey][]candidate
+}
+
+// NewIndex returns an empty Index ready for Add.
+func NewIndex() *Index {
+	return &Index{
+		entries:  make(map[string]*indexedSignature),
+		inverted: make(map[gramKey][]candidate),
+	}
+}
+
+// Add parses hash and inserts it into the index under id. It returns
+// ErrDuplicateID if id is already present, or the error splitSsdeep
+// returns if hash is malformed.
+func (idx *Index) Add(id, hash string) error {
+	_, blockSize, part1, part2, err := splitSsdeep(hash)
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if _, ok := idx.entries[id]; ok {
+		return ErrDuplicateID
+	}
+
+	entry := &indexedSignature{hash: hash, blockSize: blockSize, part1: part1, part2: part2}
+	idx.entries[id] = entry
+
+	for _, gram := range grams(part1) {
+		key := gramKey{blockSize: blockSize, gram: gram}
+		idx.inverted[key] = append(idx.inverted[key], candidate{id: id, half: 1})
+	}
+	for _, gram := range grams(part2) {
+		key := gramKey{blockSize: blockSize * 2, gram: gram}
+		idx.inverted[key] = append(idx.inverted[key], candidate{id: id, half: 2})
+	}
+
+	return nil
+}
+
+// Remove drops id from the index. It is a no-op if id is not present.
+func (idx *Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.entries[id]
+	if !ok {
+		return
+	}
+	delete(idx.entries, id)
+
+	idx.unpost(entry.blockSize, entry.part1, id, 1)
+	idx.unpost(entry.blockSize*2, entry.part2, id, 2)
+}
+
+func (idx *Index) unpost(blockSize int, part string, id string, half int) {
+	for _, gram := range grams(part) {
+		key := gramKey{blockSize: blockSize, gram: gram}
+		postings := idx.inverted[key]
+		for i, c := range postings {
+			if c.id == id && c.half == half {
+				postings = append(postings[:i], postings[i+1:]...)
+				break
+			}
+		}
+		if len(postings) == 0 {
+			delete(idx.inverted, key)
+		} else {
+			idx.inverted[key] = postings
+		}
+	}
+}
+
+// Len returns the number of signatures currently in the index.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
+
+// Search returns every indexed signature that scores at or above
+// threshold against hash, using the inverted index to avoid scoring the
+// whole corpus. Only signatures sharing an indexed substring with hash
+// at a comparable block size are scored, so with threshold <= 0 the
+// zero-scoring signatures outside that candidate set are not returned.
+func (idx *Index) Search(hash string, threshold int) ([]Match, error) {
+	return idx.search(hash, threshold, -1)
+}
+
+// SearchTopK returns the k highest-scoring indexed signatures against
+// hash, in descending order of score. Fewer than k may be returned if
+// the candidate set or the corpus is smaller than k. k <= 0 returns no
+// matches.
+func (idx *Index) SearchTopK(hash string, k int) ([]Match, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	matches, err := idx.search(hash, 0, k)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+func (idx *Index) search(hash string, threshold int, topK int) ([]Match, error) {
+	_, blockSize, part1, part2, err := splitSsdeep(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var candidateIDs []string
+	collect := func(bs int, part string) {
+		for _, gram := range grams(part) {
+			for _, c := range idx.inverted[gramKey{blockSize: bs, gram: gram}] {
+				if !seen[c.id] {
+					seen[c.id] = true
+					candidateIDs = append(candidateIDs, c.id)
+				}
+			}
+		}
+	}
+	collect(blockSize, part1)
+	collect(blockSize*2, part2)
+
+	var matches []Match
+	for _, id := range candidateIDs {
+		entry := idx.entries[id]
+		score, err := Distance(hash, entry.hash)
+		if err != nil {
+			continue
+		}
+		if threshold >= 0 && score < threshold {
+			continue
+		}
+		matches = append(matches, Match{ID: id, Score: score})
+	}
+
+	if topK >= 0 {
+		sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	}
+
+	return matches, nil
+}
+
+// grams returns the set of distinct rollingWindow-sized substrings of s,
+// the same substrings hasCommonSubstring checks for a shared hit between
+// two signature halves. Halves shorter than rollingWindow (common for
+// small-file inputs) can never share a hasCommonSubstring hit with
+// anything, so scoreDistance can only ever score them through Distance's
+// whole-signature exact-match shortcut; grams falls back to bucketing
+// them by their whole, literal content so that shortcut's candidates -
+// including an exact self-match - are still found instead of silently
+// dropped.
+func grams(s string) []string {
+	if len(s) < rollingWindow {
+		return []string{s}
+	}
+	seen := make(map[string]bool, len(s)-rollingWindow+1)
+	out := make([]string, 0, len(s)-rollingWindow+1)
+	for i := 0; i+rollingWindow <= len(s); i++ {
+		g := s[i : i+rollingWindow]
+		if !seen[g] {
+			seen[g] = true
+			out = append(out, g)
+		}
+	}
+	return out
+}
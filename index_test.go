@@ -0,0 +1,158 @@
+package ssdeep
+
+import (
+	"fmt"
+	"testing"
+)
+
+const (
+	sigA = "3:ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghij:KLMNOPQRSTUVWXYZabcdefghijklmnop"
+	sigB = "3:ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghik:KLMNOPQRSTUVWXYZabcdefghijklmnoq"
+	sigC = "3:0123456789zyxwvutsrqponmlkjihgfedcb:9876543210ZYXWVUTSRQPONMLKJIHGF"
+)
+
+func TestIndexAddSearchRemove(t *testing.T) {
+	idx := NewIndex()
+	if err := idx.Add("a", sigA); err != nil {
+		t.Fatalf("Add(a): %v", err)
+	}
+	if err := idx.Add("b", sigB); err != nil {
+		t.Fatalf("Add(b): %v", err)
+	}
+	if err := idx.Add("c", sigC); err != nil {
+		t.Fatalf("Add(c): %v", err)
+	}
+	if got := idx.Len(); got != 3 {
+		t.Fatalf("Len() = %d, want 3", got)
+	}
+
+	matches, err := idx.Search(sigA, 1)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	found := make(map[string]bool)
+	for _, m := range matches {
+		found[m.ID] = true
+	}
+	if !found["a"] || !found["b"] {
+		t.Fatalf("Search(sigA) = %+v, want a and b (near-identical signatures)", matches)
+	}
+	if found["c"] {
+		t.Fatalf("Search(sigA) unexpectedly matched unrelated signature c: %+v", matches)
+	}
+
+	idx.Remove("a")
+	if got := idx.Len(); got != 2 {
+		t.Fatalf("Len() after Remove = %d, want 2", got)
+	}
+	matches, err = idx.Search(sigA, 1)
+	if err != nil {
+		t.Fatalf("Search after Remove: %v", err)
+	}
+	for _, m := range matches {
+		if m.ID == "a" {
+			t.Fatalf("Search still returned removed id a: %+v", matches)
+		}
+	}
+}
+
+func TestIndexAddDuplicateID(t *testing.T) {
+	idx := NewIndex()
+	if err := idx.Add("a", sigA); err != nil {
+		t.Fatalf("Add(a): %v", err)
+	}
+	if err := idx.Add("a", sigB); err != ErrDuplicateID {
+		t.Fatalf("Add(a) again = %v, want ErrDuplicateID", err)
+	}
+}
+
+func TestIndexSearchTopK(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("a", sigA)
+	idx.Add("b", sigB)
+	idx.Add("c", sigC)
+
+	matches, err := idx.SearchTopK(sigA, 1)
+	if err != nil {
+		t.Fatalf("SearchTopK: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("SearchTopK(k=1) returned %d matches, want 1", len(matches))
+	}
+	if matches[0].ID != "a" {
+		t.Fatalf("SearchTopK(k=1) = %+v, want the exact match (a) first", matches)
+	}
+}
+
+func TestIndexSearchTopKNonPositiveK(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("a", sigA)
+
+	for _, k := range []int{0, -1, -100} {
+		matches, err := idx.SearchTopK(sigA, k)
+		if err != nil {
+			t.Fatalf("SearchTopK(k=%d): %v", k, err)
+		}
+		if len(matches) != 0 {
+			t.Fatalf("SearchTopK(k=%d) = %+v, want none", k, matches)
+		}
+	}
+}
+
+func TestIndexSearchShortSignature(t *testing.T) {
+	const short = "3:ab:cd"
+	idx := NewIndex()
+	if err := idx.Add("short", short); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	matches, err := idx.Search(short, 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	found := false
+	for _, m := range matches {
+		if m.ID == "short" {
+			found = true
+			if m.Score != 100 {
+				t.Fatalf("Search(short) self-match score = %d, want 100", m.Score)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("Search(short) = %+v, want it to find its own exact self-match", matches)
+	}
+}
+
+// corpus builds n distinct, internally-consistent ssdeep signatures for
+// benchmarking the index at scale.
+func corpus(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprintf("3:%040dABCDEFGHIJKLMNOPQRSTUVWXYZ:%040dabcdefghijklmnopqrstuvwxyz", i, i)
+	}
+	return out
+}
+
+func BenchmarkIndexAdd(b *testing.B) {
+	hashes := corpus(b.N)
+	idx := NewIndex()
+	b.ResetTimer()
+	for i, hash := range hashes {
+		idx.Add(fmt.Sprintf("id-%d", i), hash)
+	}
+}
+
+func BenchmarkIndexSearch(b *testing.B) {
+	const n = 10000
+	hashes := corpus(n)
+	idx := NewIndex()
+	for i, hash := range hashes {
+		idx.Add(fmt.Sprintf("id-%d", i), hash)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.Search(hashes[i%n], 50)
+	}
+}
@@ -0,0 +1,265 @@
+package ssdeep
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// Pair is one scored comparison produced by DistancePairs or
+// DistanceStream.
+type Pair struct {
+	A, B  int
+	Score int
+}
+
+// parsedHash is a signature that has already been through splitSsdeep,
+// so a batch of N comparisons parses each input once instead of
+// re-parsing it on every pairing.
+type parsedHash struct {
+	flavor       string
+	blockSize    int
+	part1, part2 string
+}
+
+func parseAll(hashes []string) ([]parsedHash, error) {
+	parsed := make([]parsedHash, len(hashes))
+	for i, hash := range hashes {
+		flavor, blockSize, part1, part2, err := splitSsdeep(hash)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = parsedHash{flavor: flavor, blockSize: blockSize, part1: part1, part2: part2}
+	}
+	return parsed, nil
+}
+
+// upperBound is the highest score scoreDistance could possibly return
+// for a pair at blockSize, given their string lengths - the same cap
+// scoreDistance itself applies, and only applies, below
+// blockSizeSmallLimit; at or above it scoreDistance can return up to
+// 100 regardless of length. Computing it up front lets batch
+// comparisons skip hasCommonSubstring and distance entirely for pairs
+// that can never reach threshold.
+func upperBound(blockSize, len1, len2 int) int {
+	if blockSize >= blockSizeSmallLimit {
+		return 100
+	}
+	minLen := math.Min(float64(len1), float64(len2))
+	return int(float64(blockSize) / blockMin * minLen)
+}
+
+// distanceParsed scores two already-split signatures the way Distance
+// does, but rejects pairs that cannot possibly reach threshold before
+// paying for scoreDistance's common-substring scan. When threshold > 0,
+// every sub-threshold result is normalized to exactly 0, whether or not
+// it was the cheap upperBound fast path that caught it, so callers see
+// a clean thresholded mask rather than the fast path's behavior leaking
+// into the output.
+func distanceParsed(a, b parsedHash, threshold int) int {
+	score := rawDistanceParsed(a, b, threshold)
+	if threshold > 0 && score < threshold {
+		return 0
+	}
+	return score
+}
+
+func rawDistanceParsed(a, b parsedHash, threshold int) int {
+	if a.flavor != b.flavor {
+		return 0
+	}
+
+	if a.blockSize == b.blockSize &&
+		len(a.part1) == len(b.part1) && len(a.part2) == len(b.part2) &&
+		a.part1 == b.part1 && a.part2 == b.part2 {
+		return 100
+	}
+
+	if a.blockSize != b.blockSize && a.blockSize != b.blockSize*2 && b.blockSize != a.blockSize*2 {
+		return 0
+	}
+
+	reachable := func(blockSize int, s1, s2 string) bool {
+		return threshold <= 0 || upperBound(blockSize, len(s1), len(s2)) >= threshold
+	}
+
+	switch {
+	case a.blockSize == b.blockSize:
+		var score int
+		if reachable(a.blockSize, a.part1, b.part1) {
+			score = scoreDistance(a.part1, b.part1, a.blockSize)
+		}
+		if reachable(a.blockSize*2, a.part2, b.part2) {
+			if d2 := scoreDistance(a.part2, b.part2, a.blockSize*2); d2 > score {
+				score = d2
+			}
+		}
+		return score
+	case a.blockSize == b.blockSize*2:
+		if !reachable(a.blockSize, a.part1, b.part2) {
+			return 0
+		}
+		return scoreDistance(a.part1, b.part2, a.blockSize)
+	default:
+		if !reachable(b.blockSize, a.part2, b.part1) {
+			return 0
+		}
+		return scoreDistance(a.part2, b.part1, b.blockSize)
+	}
+}
+
+// workerCount returns the number of goroutines to fan a batch of n
+// independent jobs across.
+func workerCount(n int) int {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// DistanceMatrix computes all-pairs match scores for hashes using a
+// worker pool sized by runtime.GOMAXPROCS. Each input is split via
+// splitSsdeep exactly once and reused for every comparison involving it.
+// When threshold > 0, pairs that cannot possibly reach it are skipped
+// before the expensive common-substring scan. The returned matrix is
+// symmetric with 100 on the diagonal.
+func DistanceMatrix(hashes []string, threshold int) ([][]int, error) {
+	parsed, err := parseAll(hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(parsed)
+	matrix := make([][]int, n)
+	for i := range matrix {
+		matrix[i] = make([]int, n)
+		matrix[i][i] = 100
+	}
+	if n < 2 {
+		return matrix, nil
+	}
+
+	type job struct{ i, j int }
+	jobs := make(chan job, n)
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount(n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for jb := range jobs {
+				score := distanceParsed(parsed[jb.i], parsed[jb.j], threshold)
+				matrix[jb.i][jb.j] = score
+				matrix[jb.j][jb.i] = score
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			jobs <- job{i, j}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return matrix, nil
+}
+
+// DistancePairs cross-compares every hash in a against every hash in b
+// using the same worker pool and fast-path rejection as DistanceMatrix.
+// When threshold > 0, only pairs scoring at or above it are returned.
+func DistancePairs(a, b []string, threshold int) ([]Pair, error) {
+	parsedA, err := parseAll(a)
+	if err != nil {
+		return nil, err
+	}
+	parsedB, err := parseAll(b)
+	if err != nil {
+		return nil, err
+	}
+
+	type job struct{ i, j int }
+	total := len(parsedA) * len(parsedB)
+	if total == 0 {
+		return nil, nil
+	}
+	jobs := make(chan job, total)
+	results := make(chan Pair, total)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount(total); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for jb := range jobs {
+				score := distanceParsed(parsedA[jb.i], parsedB[jb.j], threshold)
+				if threshold > 0 && score < threshold {
+					continue
+				}
+				results <- Pair{A: jb.i, B: jb.j, Score: score}
+			}
+		}()
+	}
+	for i := range parsedA {
+		for j := range parsedB {
+			jobs <- job{i, j}
+		}
+	}
+	close(jobs)
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var pairs []Pair
+	for p := range results {
+		pairs = append(pairs, p)
+	}
+	return pairs, nil
+}
+
+// DistanceStream is DistanceMatrix's streaming counterpart for corpora
+// too large to materialize as an N² matrix: it emits every pair scoring
+// at or above threshold on the returned channel as they're found, and
+// closes it once all pairs have been scored.
+func DistanceStream(hashes []string, threshold int) (<-chan Pair, error) {
+	parsed, err := parseAll(hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(parsed)
+	type job struct{ i, j int }
+	jobs := make(chan job, n)
+	out := make(chan Pair, n)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount(n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for jb := range jobs {
+				score := distanceParsed(parsed[jb.i], parsed[jb.j], threshold)
+				if score >= threshold {
+					out <- Pair{A: jb.i, B: jb.j, Score: score}
+				}
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				jobs <- job{i, j}
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(out)
+	}()
+
+	return out, nil
+}
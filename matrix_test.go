@@ -0,0 +1,146 @@
+package ssdeep
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDistanceMatrixExactDuplicate(t *testing.T) {
+	hashes := []string{sigA, sigA, sigB}
+	matrix, err := DistanceMatrix(hashes, 0)
+	if err != nil {
+		t.Fatalf("DistanceMatrix: %v", err)
+	}
+	if matrix[0][1] != 100 {
+		t.Fatalf("matrix[0][1] = %d, want 100 for two identical signatures", matrix[0][1])
+	}
+	if matrix[1][0] != 100 {
+		t.Fatalf("matrix[1][0] = %d, want 100 (matrix must be symmetric)", matrix[1][0])
+	}
+	for i := range matrix {
+		if matrix[i][i] != 100 {
+			t.Fatalf("matrix[%d][%d] = %d, want 100 on the diagonal", i, i, matrix[i][i])
+		}
+	}
+
+	want, err := Distance(sigA, sigA)
+	if err != nil {
+		t.Fatalf("Distance: %v", err)
+	}
+	if matrix[0][1] != want {
+		t.Fatalf("DistanceMatrix disagrees with Distance: %d != %d", matrix[0][1], want)
+	}
+}
+
+func TestDistancePairsFlavorMismatch(t *testing.T) {
+	classic := sigA
+	blake3Flavored := flavorBlake3 + sigA
+
+	pairs, err := DistancePairs([]string{classic}, []string{blake3Flavored}, 0)
+	if err != nil {
+		t.Fatalf("DistancePairs: %v", err)
+	}
+	for _, p := range pairs {
+		if p.Score != 0 {
+			t.Fatalf("DistancePairs scored a classic/Blake3 pair %d, want 0 (cross-flavor should never match)", p.Score)
+		}
+	}
+
+	// Distance itself must refuse the comparison outright.
+	if _, err := Distance(classic, blake3Flavored); err != ErrFlavorMismatch {
+		t.Fatalf("Distance(classic, blake3) = %v, want ErrFlavorMismatch", err)
+	}
+}
+
+func TestDistanceStreamMatchesThreshold(t *testing.T) {
+	hashes := []string{sigA, sigB, sigC}
+	ch, err := DistanceStream(hashes, 1)
+	if err != nil {
+		t.Fatalf("DistanceStream: %v", err)
+	}
+	var pairs []Pair
+	for p := range ch {
+		pairs = append(pairs, p)
+	}
+	for _, p := range pairs {
+		if p.Score < 1 {
+			t.Fatalf("DistanceStream emitted pair below threshold: %+v", p)
+		}
+	}
+	if len(pairs) == 0 {
+		t.Fatalf("DistanceStream found no pairs, want at least sigA/sigB (near-identical)")
+	}
+}
+
+// TestDistanceMatrixZeroesSubThresholdScores pins distanceParsed's
+// threshold contract: any pair scoring below a positive threshold reads
+// back as exactly 0, regardless of whether the cheap upperBound fast
+// path or the full scoreDistance computed the real value. Without this,
+// a pair the fast path let through keeps its true sub-threshold score
+// while a pair the fast path rejected reads 0, so the same corpus could
+// show two different numbers for equally-irrelevant pairs depending on
+// signature length alone.
+func TestDistanceMatrixZeroesSubThresholdScores(t *testing.T) {
+	hashes := []string{sigA, sigB}
+	real, err := Distance(sigA, sigB)
+	if err != nil {
+		t.Fatalf("Distance: %v", err)
+	}
+	if real <= 0 {
+		t.Skipf("sigA/sigB score %d, need a genuinely nonzero score to pin this contract", real)
+	}
+
+	matrix, err := DistanceMatrix(hashes, real+1)
+	if err != nil {
+		t.Fatalf("DistanceMatrix: %v", err)
+	}
+	if matrix[0][1] != 0 {
+		t.Fatalf("matrix[0][1] = %d, want 0 for a pair scoring %d below threshold %d", matrix[0][1], real, real+1)
+	}
+
+	pairs, err := DistancePairs([]string{sigA}, []string{sigB}, 0)
+	if err != nil {
+		t.Fatalf("DistancePairs: %v", err)
+	}
+	if len(pairs) != 1 || pairs[0].Score != real {
+		t.Fatalf("DistancePairs(threshold=0) = %+v, want the real score %d reported when nothing is thresholded away", pairs, real)
+	}
+}
+
+func BenchmarkDistanceMatrix(b *testing.B) {
+	const n = 200
+	hashes := corpus(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DistanceMatrix(hashes, 0); err != nil {
+			b.Fatalf("DistanceMatrix: %v", err)
+		}
+	}
+}
+
+// BenchmarkDistanceMatrixScaling demonstrates how DistanceMatrix scales
+// into corpus sizes typical of a malware/document dedup run.
+func BenchmarkDistanceMatrixScaling(b *testing.B) {
+	for _, n := range []int{1000, 5000, 10000} {
+		hashes := corpus(n)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := DistanceMatrix(hashes, 50); err != nil {
+					b.Fatalf("DistanceMatrix: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDistancePairs(b *testing.B) {
+	const n = 200
+	a := corpus(n)
+	c := corpus(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DistancePairs(a, c, 0); err != nil {
+			b.Fatalf("DistancePairs: %v", err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 )
 
 var (
@@ -14,6 +15,11 @@ var (
 
 	// ErrInvalidFormat is returned when a hash string is malformed.
 	ErrInvalidFormat = errors.New("invalid ssdeep format")
+
+	// ErrFlavorMismatch is returned by Distance when the two signatures
+	// were produced with different block-hash flavors (see
+	// HashWithOptions) and so cannot be meaningfully compared.
+	ErrFlavorMismatch = errors.New("ssdeep: cannot compare signatures from different block-hash flavors")
 )
 
 // Distance computes the match score between two fuzzy hash signatures.
@@ -22,14 +28,18 @@ var (
 // Returns an error when one of the inputs are not valid signatures.
 func Distance(hash1, hash2 string) (int, error) {
 	var score int
-	hash1BlockSize, hash1String1, hash1String2, err := splitSsdeep(hash1)
+
+	flavor1, hash1BlockSize, hash1String1, hash1String2, err := splitSsdeep(hash1)
 	if err != nil {
 		return score, err
 	}
-	hash2BlockSize, hash2String1, hash2String2, err := splitSsdeep(hash2)
+	flavor2, hash2BlockSize, hash2String1, hash2String2, err := splitSsdeep(hash2)
 	if err != nil {
 		return score, err
 	}
+	if flavor1 != flavor2 {
+		return score, ErrFlavorMismatch
+	}
 
 	if hash1BlockSize == hash2BlockSize &&
 		len(hash1String1) == len(hash2String1) && len(hash1String2) == len(hash2String2) &&
@@ -54,9 +64,17 @@ func Distance(hash1, hash2 string) (int, error) {
 	return score, nil
 }
 
-func splitSsdeep(hash string) (int, string, string, error) {
+// splitSsdeep parses a signature into its flavor (see HashWithOptions;
+// "" for classic FNV signatures), block size, and two halves.
+func splitSsdeep(hash string) (string, int, string, string, error) {
 	if hash == "" {
-		return 0, "", "", ErrEmptyHash
+		return "", 0, "", "", ErrEmptyHash
+	}
+
+	fl := ""
+	if strings.HasPrefix(hash, flavorBlake3) {
+		fl = flavorBlake3
+		hash = hash[len(flavorBlake3):]
 	}
 
 	hashBytes := []byte(hash)
@@ -73,12 +91,12 @@ func splitSsdeep(hash string) (int, string, string, error) {
 		buffer.WriteByte(b)
 	}
 	if index == 0 {
-		return 0, "", "", ErrInvalidFormat
+		return "", 0, "", "", ErrInvalidFormat
 	}
 
 	blockSize, err := strconv.Atoi(buffer.String())
 	if err != nil {
-		return blockSize, "", "", fmt.Errorf("%s: %w", ErrInvalidFormat.Error(), err)
+		return "", blockSize, "", "", fmt.Errorf("%s: %w", ErrInvalidFormat.Error(), err)
 	}
 	buffer.Reset()
 
@@ -103,7 +121,7 @@ func splitSsdeep(hash string) (int, string, string, error) {
 		}
 	}
 	if !indexUpdated {
-		return 0, "", "", ErrInvalidFormat
+		return "", 0, "", "", ErrInvalidFormat
 	}
 
 	part1 := buffer.String()
@@ -113,7 +131,7 @@ func splitSsdeep(hash string) (int, string, string, error) {
 	prev = ':'
 	for _, curr := range hashBytes[index:] {
 		if curr == ':' {
-			return 0, "", "", ErrInvalidFormat
+			return "", 0, "", "", ErrInvalidFormat
 		}
 		if curr == prev {
 			seq++
@@ -129,7 +147,7 @@ func splitSsdeep(hash string) (int, string, string, error) {
 
 	part2 := buffer.String()
 
-	return blockSize, part1, part2, nil
+	return fl, blockSize, part1, part2, nil
 }
 
 func hasCommonSubstring(h1, h2 string) bool {